@@ -1,9 +1,13 @@
 package boltstore
 
 import (
+	"context"
 	"encoding/base32"
 	"errors"
+	"fmt"
 	"net/http"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/gorilla/securecookie"
@@ -15,18 +19,46 @@ var _ sessions.Store = (*Store)(nil)
 
 var ErrInvalidId = errors.New("boltstore: invalid session id")
 
+// ErrSessionTooLong is returned by Save when the encoded session
+// payload exceeds the store's maxLength.
+var ErrSessionTooLong = errors.New("boltstore: the value to store is too long")
+
+// defaultMaxLength is the default limit on encoded session size, in
+// bytes, applied when a Store is created via New.
+const defaultMaxLength = 4096
+
+// defaultMaxAge is the default DefaultMaxAge, in seconds, applied when
+// a Store is created via New.
+const defaultMaxAge = 20 * 60
+
 // Session object store in BoltDB
+//
+// Data is kept as a string, the same on-disk shape it has always had,
+// so bolthold's gob decode of existing rows never breaks across a
+// SessionSerializer change. SessionSerializer works in []byte; the
+// conversion happens at the load/upsert boundary instead.
 type Session struct {
-	ID       string `boltholdKey:"ID"`
-	Data     string
-	Modified time.Time `boltholdIndex:"Modified"`
+	ID        string `boltholdKey:"ID"`
+	Data      string
+	Modified  time.Time `boltholdIndex:"Modified"`
+	ExpiresAt time.Time `boltholdIndex:"ExpiresAt"`
 }
 
 // BoltStore stores sessions in BoltDB
 type Store struct {
-	Codecs  []securecookie.Codec
-	Options *sessions.Options
-	store   *bolthold.Store
+	Codecs     []securecookie.Codec
+	Options    *sessions.Options
+	store      *bolthold.Store
+	serializer SessionSerializer
+	bucketName string
+	keyPrefix  string
+	maxLength  int
+
+	// DefaultMaxAge is the server-side expiration, in seconds, used for
+	// sessions whose cookie Options.MaxAge is 0 ("browser session").
+	// Those sessions have no cookie-driven expiration, but the stored
+	// row still needs a TTL so Cleanup can eventually reap it.
+	DefaultMaxAge int
 }
 
 var base32RawStdEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
@@ -39,7 +71,10 @@ func New(s *bolthold.Store, maxAge int, keyPairs ...[]byte) *Store {
 			Path:   "/",
 			MaxAge: maxAge,
 		},
-		store: s,
+		store:         s,
+		serializer:    GobSerializer{},
+		maxLength:     defaultMaxLength,
+		DefaultMaxAge: defaultMaxAge,
 	}
 
 	store.MaxAge(maxAge)
@@ -47,6 +82,80 @@ func New(s *bolthold.Store, maxAge int, keyPairs ...[]byte) *Store {
 	return store
 }
 
+// clone returns a shallow copy of the store with its own *sessions.Options,
+// so With* methods can derive an independently configured Store instead of
+// mutating the receiver (and everything else that shares it).
+func (m *Store) clone() *Store {
+	c := *m
+	opts := *m.Options
+	c.Options = &opts
+	return &c
+}
+
+// WithSerializer returns a copy of the store that uses s to encode
+// session Values for storage instead of the default GobSerializer.
+// The receiver is left unmodified.
+func (m *Store) WithSerializer(s SessionSerializer) *Store {
+	c := m.clone()
+	c.serializer = s
+	return c
+}
+
+// WithKeyPrefix returns a copy of the store that namespaces every key
+// it reads or writes with prefix, so multiple Store instances (e.g.
+// admin vs. user sessions) can share one *bolthold.Store without
+// colliding. The receiver is left unmodified.
+func (m *Store) WithKeyPrefix(prefix string) *Store {
+	c := m.clone()
+	c.keyPrefix = prefix
+	return c
+}
+
+// WithBucket returns a copy of the store that namespaces every key it
+// reads or writes with name, the same way WithKeyPrefix does. bolthold
+// derives the real bbolt bucket from the Go type name alone, so this
+// does not create a separate bbolt bucket; it folds name into the same
+// composed key as WithKeyPrefix. It exists as a distinct knob so
+// callers can keep bucket-style and per-request prefixes separate,
+// e.g. base.WithBucket("admin").WithKeyPrefix(tenantID). The receiver
+// is left unmodified.
+func (m *Store) WithBucket(name string) *Store {
+	c := m.clone()
+	c.bucketName = name
+	return c
+}
+
+// key composes the bolthold key a session ID is stored under, folding
+// in the bucket name and key prefix set via WithBucket/WithKeyPrefix.
+func (m *Store) key(id string) string {
+	return m.bucketName + m.keyPrefix + id
+}
+
+// ListIDs returns the IDs of every stored session whose key begins with
+// the store's bucket name and key prefix, with that prefix stripped.
+// Rows written by another Store whose bucket name and key prefix begin
+// with this store's (including every row, when both are empty) are
+// listed too.
+func (m *Store) ListIDs(ctx context.Context) ([]string, error) {
+	prefix := m.bucketName + m.keyPrefix
+	re := regexp.MustCompile("^" + regexp.QuoteMeta(prefix))
+
+	var matches []Session
+	err := withContext(ctx, func() error {
+		return m.store.Find(&matches, bolthold.Where("ID").RegExp(re))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(matches))
+	for i, s := range matches {
+		ids[i] = strings.TrimPrefix(s.ID, prefix)
+	}
+
+	return ids, nil
+}
+
 // Get registers and returns a session for the given name and session store.
 // It returns a new session if there are no sessions registered for the name.
 func (m *Store) Get(r *http.Request, name string) (
@@ -55,9 +164,28 @@ func (m *Store) Get(r *http.Request, name string) (
 	return sessions.GetRegistry(r).Get(m, name)
 }
 
+// GetContext is like Get but accepts a context that bounds the
+// underlying BoltDB read, allowing per-request deadlines and
+// cancellation to abort a slow lookup. Unlike Get, it does not consult
+// the session registry, since gorilla/sessions' registry has no
+// context-aware hook to thread ctx through.
+func (m *Store) GetContext(ctx context.Context, r *http.Request, name string) (
+	*sessions.Session, error,
+) {
+	return m.NewContext(ctx, r, name)
+}
+
 // New returns a session for the given name without adding it to the registry.
 func (m *Store) New(r *http.Request, name string) (
 	*sessions.Session, error,
+) {
+	return m.NewContext(context.Background(), r, name)
+}
+
+// NewContext is like New but accepts a context that bounds the
+// underlying BoltDB read.
+func (m *Store) NewContext(ctx context.Context, r *http.Request, name string) (
+	*sessions.Session, error,
 ) {
 	session := sessions.NewSession(m, name)
 	session.Options = &sessions.Options{
@@ -79,7 +207,7 @@ func (m *Store) New(r *http.Request, name string) (
 		return session, err
 	}
 
-	if err = m.load(session); err != nil {
+	if err = m.loadContext(ctx, session); err != nil {
 		if err != bolthold.ErrNotFound {
 			return session, err
 		}
@@ -91,11 +219,19 @@ func (m *Store) New(r *http.Request, name string) (
 }
 
 // Save saves all sessions registered for the current request.
-func (m *Store) Save(_ *http.Request, w http.ResponseWriter,
+func (m *Store) Save(r *http.Request, w http.ResponseWriter,
+	session *sessions.Session,
+) error {
+	return m.SaveContext(context.Background(), r, w, session)
+}
+
+// SaveContext is like Save but accepts a context that bounds the
+// underlying BoltDB write or delete.
+func (m *Store) SaveContext(ctx context.Context, _ *http.Request, w http.ResponseWriter,
 	session *sessions.Session,
 ) error {
 	if session.Options.MaxAge < 0 {
-		if err := m.delete(session); err != nil {
+		if err := m.deleteContext(ctx, session); err != nil {
 			return err
 		}
 		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
@@ -107,7 +243,7 @@ func (m *Store) Save(_ *http.Request, w http.ResponseWriter,
 			securecookie.GenerateRandomKey(32))
 	}
 
-	if err := m.upsert(session); err != nil {
+	if err := m.upsertContext(ctx, session); err != nil {
 		return err
 	}
 
@@ -135,15 +271,59 @@ func (m *Store) MaxAge(age int) {
 	}
 }
 
+// MaxLength sets the maximum length, in bytes, of an encoded session
+// payload that Save will accept. Sessions encoding to more than l bytes
+// are rejected with ErrSessionTooLong instead of being written, which
+// guards against unbounded bbolt page growth. A value of 0 disables
+// the check.
+func (m *Store) MaxLength(l int) {
+	m.maxLength = l
+}
+
+// withContext runs fn on its own goroutine and returns ctx.Err() if
+// ctx is cancelled first. bbolt transactions are synchronous, so this
+// is the only way to make a call abortable by a caller's deadline; fn
+// keeps running to completion in the background even after a cancel.
+func withContext(ctx context.Context, fn func() error) error {
+	errc := make(chan error, 1)
+	go func() { errc <- fn() }()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-errc:
+		return err
+	}
+}
+
+func (m *Store) loadContext(ctx context.Context, session *sessions.Session) error {
+	return withContext(ctx, func() error { return m.load(session) })
+}
+
+func (m *Store) upsertContext(ctx context.Context, session *sessions.Session) error {
+	return withContext(ctx, func() error { return m.upsert(session) })
+}
+
+func (m *Store) deleteContext(ctx context.Context, session *sessions.Session) error {
+	return withContext(ctx, func() error { return m.delete(session) })
+}
+
 func (m *Store) load(session *sessions.Session) error {
 	s := Session{}
-	if err := m.store.Get(session.ID, &s); err != nil {
+	if err := m.store.Get(m.key(session.ID), &s); err != nil {
 		return err
 	}
 
-	if err := securecookie.DecodeMulti(session.Name(), s.Data, &session.Values,
-		m.Codecs...); err != nil {
-		return err
+	if !s.ExpiresAt.IsZero() && time.Now().After(s.ExpiresAt) {
+		return bolthold.ErrNotFound
+	}
+
+	if err := m.serializer.Deserialize([]byte(s.Data), session); err != nil {
+		// Fall back to the legacy format, where Data held a
+		// securecookie-encoded payload directly, so rows written
+		// before the SessionSerializer split can still be read.
+		return securecookie.DecodeMulti(session.Name(), s.Data,
+			&session.Values, m.Codecs...)
 	}
 
 	return nil
@@ -160,21 +340,34 @@ func (m *Store) upsert(session *sessions.Session) error {
 		modified = time.Now()
 	}
 
-	encoded, err := securecookie.EncodeMulti(session.Name(), session.Values,
-		m.Codecs...)
+	encoded, err := m.serializer.Serialize(session)
 	if err != nil {
 		return err
 	}
 
+	if m.maxLength != 0 && len(encoded) > m.maxLength {
+		return fmt.Errorf("boltstore: encoded session is %d bytes, over the %d byte limit: %w",
+			len(encoded), m.maxLength, ErrSessionTooLong)
+	}
+
+	maxAge := session.Options.MaxAge
+	if maxAge == 0 {
+		maxAge = m.DefaultMaxAge
+	}
+
 	s := Session{
-		ID:       session.ID,
-		Data:     encoded,
+		ID:       m.key(session.ID),
+		Data:     string(encoded),
 		Modified: modified,
 	}
 
-	return m.store.Upsert(session.ID, &s)
+	if maxAge > 0 {
+		s.ExpiresAt = time.Now().Add(time.Duration(maxAge) * time.Second)
+	}
+
+	return m.store.Upsert(s.ID, &s)
 }
 
 func (m *Store) delete(session *sessions.Session) error {
-	return m.store.Delete(session.ID, &Session{})
+	return m.store.Delete(m.key(session.ID), &Session{})
 }
@@ -0,0 +1,67 @@
+package boltstore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/securecookie"
+	"github.com/uncle-gua/bolthold"
+)
+
+func newTestBoltholdStore(t *testing.T) *bolthold.Store {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "sessions.db")
+	bh, err := bolthold.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatalf("bolthold.Open: %v", err)
+	}
+	t.Cleanup(func() { bh.Close() })
+
+	return bh
+}
+
+// TestLoadRecoversLegacyData writes a Session row the way a
+// pre-SessionSerializer store would have (Data holding a raw
+// securecookie-encoded payload) and confirms New still recovers it via
+// the securecookie fallback in load, instead of erroring out.
+func TestLoadRecoversLegacyData(t *testing.T) {
+	bh := newTestBoltholdStore(t)
+	store := New(bh, 0, securecookie.GenerateRandomKey(32))
+
+	const sessionID = "legacy-session-id"
+	const cookieName = "test-session"
+
+	values := map[interface{}]interface{}{"user": "alice"}
+	legacyData, err := securecookie.EncodeMulti(cookieName, values, store.Codecs...)
+	if err != nil {
+		t.Fatalf("EncodeMulti(values): %v", err)
+	}
+
+	if err := bh.Upsert(sessionID, &Session{ID: sessionID, Data: legacyData}); err != nil {
+		t.Fatalf("seed legacy row: %v", err)
+	}
+
+	cookieValue, err := securecookie.EncodeMulti(cookieName, sessionID, store.Codecs...)
+	if err != nil {
+		t.Fatalf("EncodeMulti(sessionID): %v", err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: cookieName, Value: cookieValue})
+
+	session, err := store.New(r, cookieName)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if session.IsNew {
+		t.Fatal("New treated a legacy row as missing instead of recovering it")
+	}
+
+	if got := session.Values["user"]; got != "alice" {
+		t.Fatalf("Values[%q] = %v, want %q", "user", got, "alice")
+	}
+}
@@ -0,0 +1,80 @@
+package boltstore
+
+import (
+	"time"
+
+	"github.com/uncle-gua/bolthold"
+	"go.etcd.io/bbolt"
+)
+
+// cleanupChunkSize bounds how many expired sessions are removed in a
+// single bbolt transaction so a large sweep doesn't hold a write lock
+// long enough to starve concurrent Save/Get calls.
+const cleanupChunkSize = 500
+
+// StartCleanup runs Cleanup on the given interval until the returned
+// stop channel is closed. The returned done channel is closed once the
+// sweeper goroutine has exited, so callers can wait for a clean
+// shutdown.
+func (m *Store) StartCleanup(interval time.Duration) (chan<- struct{}, <-chan struct{}) {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				m.Cleanup()
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return stop, done
+}
+
+// Cleanup deletes sessions whose ExpiresAt has elapsed and returns the
+// number of rows purged. Matches are removed in batches of
+// cleanupChunkSize, each inside its own bbolt transaction driven off
+// the ExpiresAt index, so Cleanup never blocks other writers for the
+// whole sweep. Sessions stored without an ExpiresAt (none of MaxAge or
+// DefaultMaxAge was positive) never expire and are left alone.
+func (m *Store) Cleanup() (int, error) {
+	now := time.Now()
+
+	purged := 0
+	for {
+		query := bolthold.Where("ExpiresAt").Gt(time.Time{}).And("ExpiresAt").Lt(now).Index("ExpiresAt").Limit(cleanupChunkSize)
+
+		var n int
+		err := m.store.Bolt().Update(func(tx *bbolt.Tx) error {
+			var matches []Session
+			if err := m.store.TxFind(tx, &matches, query); err != nil {
+				return err
+			}
+
+			for i := range matches {
+				if err := m.store.TxDelete(tx, matches[i].ID, &Session{}); err != nil {
+					return err
+				}
+			}
+
+			n = len(matches)
+			return nil
+		})
+		if err != nil {
+			return purged, err
+		}
+
+		purged += n
+		if n < cleanupChunkSize {
+			return purged, nil
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package boltstore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCleanupDeletesExpiredSessions(t *testing.T) {
+	bh := newTestBoltholdStore(t)
+	store := New(bh, 0)
+
+	now := time.Now()
+
+	// More than one cleanupChunkSize so Cleanup has to loop over
+	// multiple bbolt transactions to finish the sweep.
+	const expiredCount = cleanupChunkSize + 50
+	for i := 0; i < expiredCount; i++ {
+		id := fmt.Sprintf("expired-%d", i)
+		if err := bh.Upsert(id, &Session{ID: id, ExpiresAt: now.Add(-time.Hour)}); err != nil {
+			t.Fatalf("seed expired row %d: %v", i, err)
+		}
+	}
+
+	if err := bh.Upsert("future", &Session{ID: "future", ExpiresAt: now.Add(time.Hour)}); err != nil {
+		t.Fatalf("seed future row: %v", err)
+	}
+
+	if err := bh.Upsert("never", &Session{ID: "never"}); err != nil {
+		t.Fatalf("seed never-expires row: %v", err)
+	}
+
+	purged, err := store.Cleanup()
+	if err != nil {
+		t.Fatalf("Cleanup: %v", err)
+	}
+
+	if purged != expiredCount {
+		t.Fatalf("Cleanup purged %d rows, want %d", purged, expiredCount)
+	}
+
+	ids, err := store.ListIDs(context.Background())
+	if err != nil {
+		t.Fatalf("ListIDs: %v", err)
+	}
+
+	if len(ids) != 2 {
+		t.Fatalf("ListIDs returned %d rows after Cleanup, want 2 (future + never)", len(ids))
+	}
+}
+
+func TestStartCleanupPurgesOnInterval(t *testing.T) {
+	bh := newTestBoltholdStore(t)
+	store := New(bh, 0)
+
+	if err := bh.Upsert("expired", &Session{ID: "expired", ExpiresAt: time.Now().Add(-time.Minute)}); err != nil {
+		t.Fatalf("seed expired row: %v", err)
+	}
+
+	stop, done := store.StartCleanup(10 * time.Millisecond)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		ids, err := store.ListIDs(context.Background())
+		if err != nil {
+			t.Fatalf("ListIDs: %v", err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for StartCleanup to purge the expired row")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	close(stop)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for StartCleanup to stop after close(stop)")
+	}
+}
@@ -0,0 +1,80 @@
+package boltstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/uncle-gua/bolthold"
+)
+
+func TestLoadTreatsExpiredRowAsNotFound(t *testing.T) {
+	bh := newTestBoltholdStore(t)
+	store := New(bh, 0)
+
+	const sessionID = "expired-session"
+	if err := bh.Upsert(sessionID, &Session{
+		ID:        sessionID,
+		Data:      "irrelevant",
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}); err != nil {
+		t.Fatalf("seed expired row: %v", err)
+	}
+
+	session := sessions.NewSession(store, "test-session")
+	session.ID = sessionID
+
+	if err := store.load(session); !errors.Is(err, bolthold.ErrNotFound) {
+		t.Fatalf("load: error = %v, want bolthold.ErrNotFound", err)
+	}
+}
+
+func TestLoadAcceptsUnexpiredRow(t *testing.T) {
+	bh := newTestBoltholdStore(t)
+	store := New(bh, 0).WithSerializer(fixedSizeSerializer{})
+
+	const sessionID = "live-session"
+	if err := bh.Upsert(sessionID, &Session{
+		ID:        sessionID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("seed live row: %v", err)
+	}
+
+	session := sessions.NewSession(store, "test-session")
+	session.ID = sessionID
+
+	if err := store.load(session); err != nil {
+		t.Fatalf("load: unexpected error: %v", err)
+	}
+}
+
+func TestUpsertAppliesDefaultMaxAge(t *testing.T) {
+	bh := newTestBoltholdStore(t)
+	store := New(bh, 0).WithSerializer(fixedSizeSerializer{})
+	store.DefaultMaxAge = 60
+
+	session := sessions.NewSession(store, "test-session")
+	session.ID = "browser-session"
+	session.Options.MaxAge = 0
+	session.Values["size"] = 0
+
+	if err := store.upsert(session); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	var s Session
+	if err := bh.Get(store.key(session.ID), &s); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	if s.ExpiresAt.IsZero() {
+		t.Fatal("ExpiresAt is zero, want it set from Store.DefaultMaxAge")
+	}
+
+	wantAround := time.Now().Add(60 * time.Second)
+	if diff := s.ExpiresAt.Sub(wantAround); diff < -5*time.Second || diff > 5*time.Second {
+		t.Fatalf("ExpiresAt = %v, want close to %v", s.ExpiresAt, wantAround)
+	}
+}
@@ -0,0 +1,80 @@
+package boltstore
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gorilla/sessions"
+)
+
+// fixedSizeSerializer serializes to a byte slice of a size fixed by
+// the "size" value in session.Values, so tests can hit MaxLength
+// boundaries exactly without depending on GobSerializer's encoding
+// overhead.
+type fixedSizeSerializer struct{}
+
+func (fixedSizeSerializer) Serialize(s *sessions.Session) ([]byte, error) {
+	n, _ := s.Values["size"].(int)
+	return make([]byte, n), nil
+}
+
+func (fixedSizeSerializer) Deserialize(d []byte, s *sessions.Session) error {
+	return nil
+}
+
+func TestUpsertEnforcesMaxLength(t *testing.T) {
+	const limit = 10
+
+	tests := []struct {
+		name    string
+		size    int
+		wantErr bool
+	}{
+		{"just under the limit", limit - 1, false},
+		{"at the limit", limit, false},
+		{"just over the limit", limit + 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bh := newTestBoltholdStore(t)
+			store := New(bh, 0).WithSerializer(fixedSizeSerializer{})
+			store.MaxLength(limit)
+
+			session := sessions.NewSession(store, "test-session")
+			session.ID = tt.name
+			session.Values["size"] = tt.size
+
+			err := store.upsert(session)
+
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("upsert: unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("upsert: expected an error, got nil")
+			}
+
+			if !errors.Is(err, ErrSessionTooLong) {
+				t.Fatalf("upsert: error = %v, want it to wrap ErrSessionTooLong", err)
+			}
+		})
+	}
+}
+
+func TestUpsertMaxLengthZeroDisablesCheck(t *testing.T) {
+	bh := newTestBoltholdStore(t)
+	store := New(bh, 0).WithSerializer(fixedSizeSerializer{})
+	store.MaxLength(0)
+
+	session := sessions.NewSession(store, "test-session")
+	session.ID = "unbounded"
+	session.Values["size"] = defaultMaxLength * 2
+
+	if err := store.upsert(session); err != nil {
+		t.Fatalf("upsert: unexpected error with MaxLength disabled: %v", err)
+	}
+}
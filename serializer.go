@@ -0,0 +1,64 @@
+package boltstore
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+
+	"github.com/gorilla/sessions"
+)
+
+// SessionSerializer encodes and decodes session.Values for storage in
+// BoltDB. It is distinct from the securecookie encoding used for the
+// session cookie: the cookie still needs HMAC integrity since it
+// travels to the client, but the stored blob never leaves the server,
+// so a serializer can trade that integrity check for speed.
+type SessionSerializer interface {
+	Serialize(s *sessions.Session) ([]byte, error)
+	Deserialize(d []byte, s *sessions.Session) error
+}
+
+// GobSerializer serializes session.Values with encoding/gob. It is the
+// default serializer used by New.
+type GobSerializer struct{}
+
+func (GobSerializer) Serialize(s *sessions.Session) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(s.Values); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobSerializer) Deserialize(d []byte, s *sessions.Session) error {
+	return gob.NewDecoder(bytes.NewReader(d)).Decode(&s.Values)
+}
+
+// JSONSerializer serializes session.Values with encoding/json. Since
+// JSON object keys must be strings, every key in Values must be a
+// string or Serialize returns an error.
+type JSONSerializer struct{}
+
+func (JSONSerializer) Serialize(s *sessions.Session) ([]byte, error) {
+	m := make(map[string]interface{}, len(s.Values))
+	for k, v := range s.Values {
+		ks, ok := k.(string)
+		if !ok {
+			return nil, errors.New("boltstore: non-string key in session.Values, cannot serialize to JSON")
+		}
+		m[ks] = v
+	}
+	return json.Marshal(m)
+}
+
+func (JSONSerializer) Deserialize(d []byte, s *sessions.Session) error {
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(d, &m); err != nil {
+		return err
+	}
+	for k, v := range m {
+		s.Values[k] = v
+	}
+	return nil
+}